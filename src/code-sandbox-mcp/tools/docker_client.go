@@ -6,59 +6,188 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/docker/cli/cli/connhelper"
 	"github.com/docker/docker/client"
 )
 
-// createDockerClient creates a Docker client with fallback options for different environments
+const (
+	// defaultPingTimeout bounds how long createDockerClient waits for each candidate endpoint to
+	// respond, so a dead remote doesn't block the whole probe loop for the client's default 30s.
+	defaultPingTimeout = 5 * time.Second
+)
+
+// dockerEndpointAttempt records why a single candidate Docker endpoint failed, so callers get a
+// full picture instead of only the last error.
+type dockerEndpointAttempt struct {
+	endpoint string
+	err      error
+}
+
+// dockerClientError is returned by createDockerClient when every candidate endpoint failed; it
+// enumerates each endpoint tried and the reason it was rejected.
+type dockerClientError struct {
+	attempts []dockerEndpointAttempt
+}
+
+func (e *dockerClientError) Error() string {
+	var b strings.Builder
+	b.WriteString("could not connect to a Docker daemon, tried:")
+	for _, a := range e.attempts {
+		fmt.Fprintf(&b, "\n  - %s: %v", a.endpoint, a.err)
+	}
+	return b.String()
+}
+
+// pingTimeoutFromEnv returns the per-endpoint ping timeout, overridable via
+// CSM_DOCKER_PING_TIMEOUT (a Go duration string, e.g. "2s").
+func pingTimeoutFromEnv() time.Duration {
+	if v := os.Getenv("CSM_DOCKER_PING_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return defaultPingTimeout
+}
+
+// fallbackSocketPaths returns the unix socket paths to try after DOCKER_HOST/FromEnv, in order.
+// Users can append custom sockets (e.g. Podman's rootless socket) via the colon-separated
+// CSM_DOCKER_SOCKET_PATHS env var.
+func fallbackSocketPaths() []string {
+	socketPaths := []string{"/var/run/docker.sock"}
+
+	if currentUser, userErr := user.Current(); userErr == nil {
+		socketPaths = append(socketPaths,
+			filepath.Join(currentUser.HomeDir, ".rd", "docker.sock"),                // Rancher Desktop
+			filepath.Join(currentUser.HomeDir, ".docker", "run", "docker.sock"),     // Docker Desktop
+			filepath.Join(currentUser.HomeDir, ".colima", "default", "docker.sock"), // Colima
+			fmt.Sprintf("/run/user/%s/podman/podman.sock", currentUser.Uid),         // Podman (rootless)
+		)
+	}
+
+	if extra := os.Getenv("CSM_DOCKER_SOCKET_PATHS"); extra != "" {
+		socketPaths = append(socketPaths, strings.Split(extra, ":")...)
+	}
+
+	return socketPaths
+}
+
+// pingWithTimeout verifies cli can actually reach a daemon, bounding the probe to timeout rather
+// than the client's much longer default.
+func pingWithTimeout(cli *client.Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := cli.Ping(ctx)
+	return err
+}
+
+// createDockerClient creates a Docker client, trying (in order): a remote DOCKER_HOST (tcp://
+// with mTLS, or ssh://), the standard FromEnv local resolution, and a list of common local
+// Docker/Podman socket paths.
 func createDockerClient() (*client.Client, error) {
-	// First try the standard FromEnv approach
+	var attempts []dockerEndpointAttempt
+	timeout := pingTimeoutFromEnv()
+
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		cli, err := dockerClientForHost(host)
+		if err == nil {
+			if pingErr := pingWithTimeout(cli, timeout); pingErr == nil {
+				return cli, nil
+			} else {
+				cli.Close()
+				err = pingErr
+			}
+		}
+		if err != nil {
+			attempts = append(attempts, dockerEndpointAttempt{endpoint: host, err: err})
+		}
+	}
+
+	// Fall back to the standard FromEnv resolution (covers DOCKER_HOST already handled above,
+	// plus context-based configuration docker CLI users may have set up).
 	cli, err := client.NewClientWithOpts(
 		client.FromEnv,
 		client.WithAPIVersionNegotiation(),
 	)
 	if err == nil {
-		// Test if the client can actually connect
-		_, pingErr := cli.Ping(context.Background())
-		if pingErr == nil {
+		if pingErr := pingWithTimeout(cli, timeout); pingErr == nil {
 			return cli, nil
+		} else {
+			cli.Close()
+			err = pingErr
 		}
-		cli.Close()
 	}
+	attempts = append(attempts, dockerEndpointAttempt{endpoint: "FromEnv", err: err})
 
-	// If FromEnv failed, try common Docker socket paths
-	socketPaths := []string{
-		"/var/run/docker.sock",
-	}
+	// Try each local socket path.
+	for _, socketPath := range fallbackSocketPaths() {
+		if _, statErr := os.Stat(socketPath); statErr != nil {
+			attempts = append(attempts, dockerEndpointAttempt{endpoint: socketPath, err: statErr})
+			continue
+		}
 
-	// Add user-specific paths for common Docker alternatives
-	if currentUser, userErr := user.Current(); userErr == nil {
-		userPaths := []string{
-			filepath.Join(currentUser.HomeDir, ".rd", "docker.sock"),                // Rancher Desktop
-			filepath.Join(currentUser.HomeDir, ".docker", "run", "docker.sock"),     // Docker Desktop
-			filepath.Join(currentUser.HomeDir, ".colima", "default", "docker.sock"), // Colima
+		cli, err := client.NewClientWithOpts(
+			client.WithHost("unix://"+socketPath),
+			client.WithAPIVersionNegotiation(),
+		)
+		if err != nil {
+			attempts = append(attempts, dockerEndpointAttempt{endpoint: socketPath, err: err})
+			continue
+		}
+		if pingErr := pingWithTimeout(cli, timeout); pingErr == nil {
+			return cli, nil
+		} else {
+			cli.Close()
+			attempts = append(attempts, dockerEndpointAttempt{endpoint: socketPath, err: pingErr})
 		}
-		socketPaths = append(socketPaths, userPaths...)
 	}
 
-	// Try each socket path
-	for _, socketPath := range socketPaths {
-		if _, statErr := os.Stat(socketPath); statErr == nil {
-			// Socket exists, try to connect
-			cli, err := client.NewClientWithOpts(
-				client.WithHost("unix://"+socketPath),
-				client.WithAPIVersionNegotiation(),
-			)
-			if err == nil {
-				// Test if the client can actually connect
-				_, pingErr := cli.Ping(context.Background())
-				if pingErr == nil {
-					return cli, nil
+	return nil, &dockerClientError{attempts: attempts}
+}
+
+// dockerClientForHost builds a client for an explicit DOCKER_HOST, handling the tcp:// (mTLS)
+// and ssh:// transports in addition to what FromEnv already covers.
+func dockerClientForHost(host string) (*client.Client, error) {
+	switch {
+	case strings.HasPrefix(host, "ssh://"):
+		helper, err := connhelper.GetConnectionHelper(host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up ssh connection helper: %w", err)
+		}
+		return client.NewClientWithOpts(
+			client.WithHost(helper.Host),
+			client.WithDialContext(helper.Dialer),
+			client.WithAPIVersionNegotiation(),
+		)
+
+	case strings.HasPrefix(host, "tcp://"):
+		opts := []client.Opt{
+			client.WithHost(host),
+			client.WithAPIVersionNegotiation(),
+		}
+		if certPath := os.Getenv("DOCKER_CERT_PATH"); certPath != "" {
+			tlsVerify := true
+			if v := os.Getenv("DOCKER_TLS_VERIFY"); v != "" {
+				if parsed, err := strconv.ParseBool(v); err == nil {
+					tlsVerify = parsed
 				}
-				cli.Close()
+			}
+			opts = append(opts, client.WithTLSClientConfig(
+				filepath.Join(certPath, "ca.pem"),
+				filepath.Join(certPath, "cert.pem"),
+				filepath.Join(certPath, "key.pem"),
+			))
+			if !tlsVerify {
+				return nil, fmt.Errorf("DOCKER_TLS_VERIFY=0 with DOCKER_CERT_PATH set is not supported; mTLS verification cannot be disabled for remote endpoints")
 			}
 		}
-	}
+		return client.NewClientWithOpts(opts...)
 
-	return nil, fmt.Errorf("could not connect to Docker daemon. Tried standard connection and socket paths: %v", socketPaths)
+	default:
+		// Anything else (unix://, npipe://, fd://) is handled by the standard FromEnv path.
+		return nil, fmt.Errorf("unsupported DOCKER_HOST scheme for direct dial: %s", host)
+	}
 }
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDockerClientForHostUnsupportedScheme(t *testing.T) {
+	_, err := dockerClientForHost("unix:///var/run/docker.sock")
+	if err == nil {
+		t.Fatal("expected an error for a scheme dockerClientForHost doesn't handle directly")
+	}
+}
+
+func TestDockerClientForHostTCPWithoutTLS(t *testing.T) {
+	t.Setenv("DOCKER_CERT_PATH", "")
+
+	cli, err := dockerClientForHost("tcp://example.invalid:2375")
+	if err != nil {
+		t.Fatalf("unexpected error building a plain tcp:// client: %v", err)
+	}
+	cli.Close()
+}
+
+func TestDockerClientForHostTCPWithTLSVerifyDisabledIsRejected(t *testing.T) {
+	t.Setenv("DOCKER_CERT_PATH", t.TempDir())
+	t.Setenv("DOCKER_TLS_VERIFY", "0")
+
+	_, err := dockerClientForHost("tcp://example.invalid:2376")
+	if err == nil {
+		t.Fatal("expected an error when DOCKER_TLS_VERIFY=0 is combined with DOCKER_CERT_PATH")
+	}
+}
+
+func TestDockerClientForHostTCPWithMissingCertFiles(t *testing.T) {
+	t.Setenv("DOCKER_CERT_PATH", t.TempDir())
+	t.Setenv("DOCKER_TLS_VERIFY", "1")
+
+	_, err := dockerClientForHost("tcp://example.invalid:2376")
+	if err == nil {
+		t.Fatal("expected an error when DOCKER_CERT_PATH points at a directory with no cert files")
+	}
+}
+
+func TestDockerClientErrorEnumeratesEveryAttempt(t *testing.T) {
+	err := &dockerClientError{attempts: []dockerEndpointAttempt{
+		{endpoint: "tcp://remote:2375", err: errString("connection refused")},
+		{endpoint: "/var/run/docker.sock", err: errString("no such file or directory")},
+	}}
+
+	msg := err.Error()
+	if !strings.Contains(msg, "tcp://remote:2375") || !strings.Contains(msg, "connection refused") {
+		t.Errorf("error message missing first attempt detail: %q", msg)
+	}
+	if !strings.Contains(msg, "/var/run/docker.sock") || !strings.Contains(msg, "no such file or directory") {
+		t.Errorf("error message missing second attempt detail: %q", msg)
+	}
+}
+
+func TestPingTimeoutFromEnvDefault(t *testing.T) {
+	t.Setenv("CSM_DOCKER_PING_TIMEOUT", "")
+	if got := pingTimeoutFromEnv(); got != defaultPingTimeout {
+		t.Fatalf("got %v, want default %v", got, defaultPingTimeout)
+	}
+}
+
+func TestPingTimeoutFromEnvOverride(t *testing.T) {
+	t.Setenv("CSM_DOCKER_PING_TIMEOUT", "2s")
+	if got := pingTimeoutFromEnv(); got.String() != "2s" {
+		t.Fatalf("got %v, want 2s", got)
+	}
+}
+
+func TestFallbackSocketPathsIncludesExtraEnvPaths(t *testing.T) {
+	t.Setenv("CSM_DOCKER_SOCKET_PATHS", "/run/user/1000/podman/podman.sock:/custom/docker.sock")
+
+	paths := fallbackSocketPaths()
+	found := map[string]bool{}
+	for _, p := range paths {
+		found[p] = true
+	}
+	if !found["/run/user/1000/podman/podman.sock"] || !found["/custom/docker.sock"] {
+		t.Fatalf("fallbackSocketPaths() = %v, want it to include the CSM_DOCKER_SOCKET_PATHS entries", paths)
+	}
+}
@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+// imageCoordinatorOptions configures DockerImageCoordinator's caching and concurrency behavior.
+type imageCoordinatorOptions struct {
+	// PullTTL is how long a successful pull is remembered; repeat calls within this window
+	// skip the ImageInspect+pull dance entirely.
+	PullTTL time.Duration
+	// NegativeTTL is how long an image-not-found result is remembered, so repeated calls for a
+	// bad reference fail fast instead of re-attempting the pull.
+	NegativeTTL time.Duration
+	// MaxConcurrentPulls bounds how many distinct image pulls may be in flight at once.
+	MaxConcurrentPulls int
+}
+
+const (
+	defaultPullTTL            = 10 * time.Minute
+	defaultNegativeTTL        = 30 * time.Second
+	defaultMaxConcurrentPulls = 4
+)
+
+// imageCoordinatorOptionsFromEnv builds imageCoordinatorOptions from CSM_IMAGE_PULL_TTL,
+// CSM_IMAGE_NEGATIVE_TTL and CSM_IMAGE_MAX_CONCURRENT_PULLS, falling back to defaults for any
+// variable that is unset or invalid.
+func imageCoordinatorOptionsFromEnv() imageCoordinatorOptions {
+	opts := imageCoordinatorOptions{
+		PullTTL:            defaultPullTTL,
+		NegativeTTL:        defaultNegativeTTL,
+		MaxConcurrentPulls: defaultMaxConcurrentPulls,
+	}
+	if v := os.Getenv("CSM_IMAGE_PULL_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			opts.PullTTL = d
+		}
+	}
+	if v := os.Getenv("CSM_IMAGE_NEGATIVE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			opts.NegativeTTL = d
+		}
+	}
+	if v := os.Getenv("CSM_IMAGE_MAX_CONCURRENT_PULLS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			opts.MaxConcurrentPulls = n
+		}
+	}
+	return opts
+}
+
+// pullKey identifies a coordinated pull. It includes registryAuth (not just the image
+// reference) because two callers asking for the same image with different credentials must not
+// collapse onto a single pull - whichever caller's auth ran first would silently decide the
+// outcome for the others.
+type pullKey struct {
+	image        string
+	registryAuth string
+}
+
+// imageCacheEntry remembers the outcome of the most recent completed pull for an image so that
+// calls within the TTL window can skip re-pulling.
+type imageCacheEntry struct {
+	cachedAt time.Time
+	ttl      time.Duration
+	err      error
+}
+
+func (e *imageCacheEntry) expired() bool {
+	return time.Since(e.cachedAt) > e.ttl
+}
+
+// inFlightPull tracks a pull that is currently running, so concurrent callers for the same
+// (image, registryAuth) pair collapse onto a single underlying ImagePull.
+type inFlightPull struct {
+	done chan struct{}
+	err  error
+}
+
+// DockerImageCoordinator deduplicates concurrent pulls of the same (image, registryAuth) pair
+// and caches recent pull outcomes (positive and negative) so repeat InitializeEnvironment calls
+// avoid redundant ImageInspect/ImagePull round-trips. Safe for concurrent use.
+//
+// Pulls run against the coordinator's own long-lived Docker client rather than a client
+// borrowed from whichever caller happened to start the pull - a caller's createContainer defers
+// cli.Close() on its own client, which would otherwise race every other waiter still using that
+// in-flight pull.
+type DockerImageCoordinator struct {
+	opts imageCoordinatorOptions
+
+	mu       sync.Mutex
+	inFlight map[pullKey]*inFlightPull
+	cache    map[pullKey]*imageCacheEntry
+
+	sem chan struct{}
+
+	clientMu sync.Mutex
+	cli      *client.Client
+
+	// pullFn performs the actual pull for a (image, registryAuth) pair. It defaults to
+	// defaultPull (which pulls via the coordinator's own client) but tests may swap it out for
+	// a fake so dedup/cache/TTL behavior can be exercised without a Docker daemon.
+	pullFn func(ctx context.Context, image, registryAuth string) error
+}
+
+// NewDockerImageCoordinator builds a coordinator with the given options.
+func NewDockerImageCoordinator(opts imageCoordinatorOptions) *DockerImageCoordinator {
+	if opts.MaxConcurrentPulls <= 0 {
+		opts.MaxConcurrentPulls = defaultMaxConcurrentPulls
+	}
+	c := &DockerImageCoordinator{
+		opts:     opts,
+		inFlight: make(map[pullKey]*inFlightPull),
+		cache:    make(map[pullKey]*imageCacheEntry),
+		sem:      make(chan struct{}, opts.MaxConcurrentPulls),
+	}
+	c.pullFn = c.defaultPull
+	return c
+}
+
+// defaultPull pulls image via the coordinator's own long-lived Docker client.
+func (c *DockerImageCoordinator) defaultPull(ctx context.Context, image, registryAuth string) error {
+	cli, err := c.dockerClient()
+	if err != nil {
+		return err
+	}
+	return pullImage(ctx, cli, image, registryAuth)
+}
+
+var (
+	imageCoordinatorOnce sync.Once
+	imageCoordinator     *DockerImageCoordinator
+)
+
+// getImageCoordinator returns the process-wide coordinator shared by every createContainer
+// call, built from env vars on first use.
+func getImageCoordinator() *DockerImageCoordinator {
+	imageCoordinatorOnce.Do(func() {
+		imageCoordinator = NewDockerImageCoordinator(imageCoordinatorOptionsFromEnv())
+	})
+	return imageCoordinator
+}
+
+// dockerClient lazily builds (and reuses) the coordinator's own Docker client, independent of
+// any caller's client lifecycle.
+func (c *DockerImageCoordinator) dockerClient() (*client.Client, error) {
+	c.clientMu.Lock()
+	defer c.clientMu.Unlock()
+
+	if c.cli != nil {
+		return c.cli, nil
+	}
+	cli, err := createDockerClient()
+	if err != nil {
+		return nil, err
+	}
+	c.cli = cli
+	return cli, nil
+}
+
+// cached returns a cached pull outcome for key, if one exists and hasn't expired.
+func (c *DockerImageCoordinator) cached(key pullKey) (*imageCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cache[key]
+	if !ok || entry.expired() {
+		return nil, false
+	}
+	return entry, true
+}
+
+// EnsurePulled pulls image (using registryAuth), collapsing concurrent callers for the same
+// (image, registryAuth) pair into a single in-flight pull and caching the outcome for PullTTL
+// (success) or NegativeTTL (image not found, as classified by notFound). Set forceFresh to
+// bypass the cache (positive and negative entries alike), e.g. for the "always" pull policy; concurrent in-flight
+// dedup still applies. Each caller's ctx governs only its own wait - cancelling one waiter's
+// context never aborts the underlying pull for the others, since the pull itself runs detached
+// on a background context against the coordinator's own client.
+func (c *DockerImageCoordinator) EnsurePulled(ctx context.Context, image string, registryAuth string, forceFresh bool, notFound func(error) bool) error {
+	key := pullKey{image: image, registryAuth: registryAuth}
+
+	if !forceFresh {
+		if entry, ok := c.cached(key); ok {
+			return entry.err
+		}
+	}
+
+	c.mu.Lock()
+	flight, exists := c.inFlight[key]
+	if !exists {
+		flight = &inFlightPull{done: make(chan struct{})}
+		c.inFlight[key] = flight
+		c.mu.Unlock()
+
+		go c.runPull(key, flight, notFound)
+	} else {
+		c.mu.Unlock()
+	}
+
+	select {
+	case <-flight.done:
+		return flight.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runPull executes the actual pull on behalf of every waiter on flight, using a background
+// context (decoupled from any single waiter's cancellation) and a semaphore slot so
+// MaxConcurrentPulls is respected.
+func (c *DockerImageCoordinator) runPull(key pullKey, flight *inFlightPull, notFound func(error) bool) {
+	c.sem <- struct{}{}
+	defer func() { <-c.sem }()
+
+	err := c.pullFn(context.Background(), key.image, key.registryAuth)
+	flight.err = err
+
+	ttl := c.opts.PullTTL
+	if err != nil {
+		if notFound != nil && notFound(err) {
+			ttl = c.opts.NegativeTTL
+		} else {
+			ttl = 0 // transport/timeout failures aren't cached, so the next call retries immediately
+		}
+	}
+
+	c.mu.Lock()
+	delete(c.inFlight, key)
+	if ttl > 0 {
+		c.cache[key] = &imageCacheEntry{cachedAt: time.Now(), ttl: ttl, err: err}
+	}
+	c.mu.Unlock()
+
+	// Release waiters only after the in-flight entry is gone and the cache is updated, so a call
+	// made right after EnsurePulled returns never joins this already-finished pull.
+	close(flight.done)
+}
@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCoordinator(t *testing.T, opts imageCoordinatorOptions) *DockerImageCoordinator {
+	t.Helper()
+	return NewDockerImageCoordinator(opts)
+}
+
+func TestEnsurePulledDedupesConcurrentCallers(t *testing.T) {
+	c := newTestCoordinator(t, imageCoordinatorOptions{
+		PullTTL: time.Minute, NegativeTTL: time.Second, MaxConcurrentPulls: 4,
+	})
+
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	c.pullFn = func(ctx context.Context, image, registryAuth string) error {
+		atomic.AddInt32(&calls, 1)
+		close(started)
+		<-release
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.EnsurePulled(context.Background(), "same/image:latest", "", false, nil)
+		}(i)
+	}
+
+	<-started
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("pullFn called %d times, want 1 (concurrent callers should dedup)", got)
+	}
+	for i, err := range results {
+		if err != nil {
+			t.Errorf("result[%d] = %v, want nil", i, err)
+		}
+	}
+}
+
+func TestEnsurePulledKeysByImageAndRegistryAuth(t *testing.T) {
+	c := newTestCoordinator(t, imageCoordinatorOptions{
+		PullTTL: time.Minute, NegativeTTL: time.Second, MaxConcurrentPulls: 4,
+	})
+
+	var calls int32
+	c.pullFn = func(ctx context.Context, image, registryAuth string) error {
+		atomic.AddInt32(&calls, 1)
+		if registryAuth == "bad-creds" {
+			return errors.New("unauthorized")
+		}
+		return nil
+	}
+
+	errA := c.EnsurePulled(context.Background(), "private/image:latest", "bad-creds", false, nil)
+	errB := c.EnsurePulled(context.Background(), "private/image:latest", "good-creds", false, nil)
+
+	if errA == nil {
+		t.Error("expected call with bad-creds to fail")
+	}
+	if errB != nil {
+		t.Errorf("call with good-creds should not have been affected by the bad-creds caller: %v", errB)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("pullFn called %d times, want 2 (different registryAuth must not dedup)", got)
+	}
+}
+
+func TestEnsurePulledCachesSuccessWithinTTL(t *testing.T) {
+	c := newTestCoordinator(t, imageCoordinatorOptions{
+		PullTTL: time.Minute, NegativeTTL: time.Second, MaxConcurrentPulls: 4,
+	})
+
+	var calls int32
+	c.pullFn = func(ctx context.Context, image, registryAuth string) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := c.EnsurePulled(context.Background(), "cached/image:latest", "", false, nil); err != nil {
+			t.Fatalf("unexpected error on call %d: %v", i, err)
+		}
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("pullFn called %d times, want 1 (repeat calls within PullTTL should hit cache)", got)
+	}
+}
+
+func TestEnsurePulledForceFreshBypassesCache(t *testing.T) {
+	c := newTestCoordinator(t, imageCoordinatorOptions{
+		PullTTL: time.Minute, NegativeTTL: time.Second, MaxConcurrentPulls: 4,
+	})
+
+	var calls int32
+	c.pullFn = func(ctx context.Context, image, registryAuth string) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}
+
+	_ = c.EnsurePulled(context.Background(), "always/image:latest", "", false, nil)
+	_ = c.EnsurePulled(context.Background(), "always/image:latest", "", true, nil)
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("pullFn called %d times, want 2 (forceFresh must bypass the cache)", got)
+	}
+}
+
+func TestEnsurePulledNegativeCacheExpires(t *testing.T) {
+	c := newTestCoordinator(t, imageCoordinatorOptions{
+		PullTTL: time.Minute, NegativeTTL: 10 * time.Millisecond, MaxConcurrentPulls: 4,
+	})
+
+	var calls int32
+	c.pullFn = func(ctx context.Context, image, registryAuth string) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("image not found")
+	}
+	notFound := func(err error) bool { return err != nil }
+
+	_ = c.EnsurePulled(context.Background(), "missing/image:latest", "", false, notFound)
+	_ = c.EnsurePulled(context.Background(), "missing/image:latest", "", false, notFound)
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("pullFn called %d times within NegativeTTL, want 1", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	_ = c.EnsurePulled(context.Background(), "missing/image:latest", "", false, notFound)
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("pullFn called %d times after NegativeTTL expired, want 2", got)
+	}
+}
+
+func TestEnsurePulledTransportErrorsAreNotCached(t *testing.T) {
+	c := newTestCoordinator(t, imageCoordinatorOptions{
+		PullTTL: time.Minute, NegativeTTL: time.Minute, MaxConcurrentPulls: 4,
+	})
+
+	var calls int32
+	c.pullFn = func(ctx context.Context, image, registryAuth string) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("connection reset by peer")
+	}
+	notFound := func(err error) bool { return false }
+
+	_ = c.EnsurePulled(context.Background(), "flaky/image:latest", "", false, notFound)
+	_ = c.EnsurePulled(context.Background(), "flaky/image:latest", "", false, notFound)
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("pullFn called %d times, want 2 (transport errors must not be cached)", got)
+	}
+}
+
+func TestEnsurePulledRespectsCallerCancellationWithoutAbortingOthers(t *testing.T) {
+	c := newTestCoordinator(t, imageCoordinatorOptions{
+		PullTTL: time.Minute, NegativeTTL: time.Second, MaxConcurrentPulls: 4,
+	})
+
+	release := make(chan struct{})
+	var calls int32
+	c.pullFn = func(ctx context.Context, image, registryAuth string) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancelledDone := make(chan error, 1)
+	go func() {
+		cancelledDone <- c.EnsurePulled(cancelCtx, "shared/image:latest", "", false, nil)
+	}()
+
+	patientDone := make(chan error, 1)
+	go func() {
+		patientDone <- c.EnsurePulled(context.Background(), "shared/image:latest", "", false, nil)
+	}()
+
+	cancel()
+	if err := <-cancelledDone; err == nil {
+		t.Error("expected the cancelled caller to get a context error")
+	}
+
+	close(release)
+	if err := <-patientDone; err != nil {
+		t.Errorf("the patient caller should still succeed once the pull completes: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("pullFn called %d times, want 1 (one waiter's cancellation must not abort the shared pull)", got)
+	}
+}
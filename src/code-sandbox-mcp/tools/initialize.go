@@ -2,6 +2,8 @@ package tools
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -9,9 +11,69 @@ import (
 
 	"github.com/docker/docker/api/types/container"
 	dockerImage "github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// pullPolicy controls when createContainer will attempt to pull an image,
+// mirroring the semantics of `docker create --pull`.
+type pullPolicy string
+
+const (
+	// pullPolicyMissing pulls the image only if it isn't present locally. This is the default.
+	pullPolicyMissing pullPolicy = "missing"
+	// pullPolicyAlways forces a fresh pull even if the image already exists locally.
+	pullPolicyAlways pullPolicy = "always"
+	// pullPolicyNever never pulls; createContainer errors if the image isn't present locally.
+	pullPolicyNever pullPolicy = "never"
+)
+
+// parsePullPolicy validates the raw "pull_policy" argument, defaulting to pullPolicyMissing.
+func parsePullPolicy(raw string) (pullPolicy, error) {
+	switch pullPolicy(raw) {
+	case "":
+		return pullPolicyMissing, nil
+	case pullPolicyAlways, pullPolicyMissing, pullPolicyNever:
+		return pullPolicy(raw), nil
+	default:
+		return "", fmt.Errorf("invalid pull_policy %q: must be one of \"always\", \"missing\", \"never\"", raw)
+	}
+}
+
+// registryAuthFromArguments builds the base64-encoded X-Registry-Auth header value from the
+// "registry_auth" argument, which may either be a pre-encoded auth token string or an object
+// with "username" and "password" fields.
+func registryAuthFromArguments(args map[string]interface{}) (string, error) {
+	raw, ok := args["registry_auth"]
+	if !ok || raw == nil {
+		return "", nil
+	}
+
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case map[string]interface{}:
+		username, _ := v["username"].(string)
+		password, _ := v["password"].(string)
+		if username == "" {
+			return "", fmt.Errorf("registry_auth.username is required when registry_auth is an object")
+		}
+		authConfig := registry.AuthConfig{
+			Username: username,
+			Password: password,
+		}
+		encoded, err := json.Marshal(authConfig)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode registry_auth: %w", err)
+		}
+		return base64.URLEncoding.EncodeToString(encoded), nil
+	default:
+		return "", fmt.Errorf("registry_auth must be a base64-encoded auth token string or an object with username/password")
+	}
+}
+
 // InitializeEnvironment creates a new container for code execution
 func InitializeEnvironment(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Get the requested Docker image or use default
@@ -21,8 +83,24 @@ func InitializeEnvironment(ctx context.Context, request mcp.CallToolRequest) (*m
 		image = "python:3.12-slim-bookworm"
 	}
 
+	rawPolicy, _ := request.Params.Arguments["pull_policy"].(string)
+	policy, err := parsePullPolicy(rawPolicy)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("Error: %v", err)), nil
+	}
+
+	registryAuth, err := registryAuthFromArguments(request.Params.Arguments)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("Error: %v", err)), nil
+	}
+
+	sandboxPolicy, err := parseSandboxPolicy(request.Params.Arguments)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("Error: %v", err)), nil
+	}
+
 	// Create and start the container
-	containerId, err := createContainer(ctx, image)
+	containerId, err := createContainer(ctx, image, policy, registryAuth, sandboxPolicy)
 	if err != nil {
 		return mcp.NewToolResultText(fmt.Sprintf("Error: %v", err)), nil
 	}
@@ -30,64 +108,129 @@ func InitializeEnvironment(ctx context.Context, request mcp.CallToolRequest) (*m
 	return mcp.NewToolResultText(fmt.Sprintf("container_id: %s", containerId)), nil
 }
 
-// createContainer creates a new Docker container and returns its ID
-func createContainer(ctx context.Context, image string) (string, error) {
-	// Try to create Docker client with multiple fallback options
-	cli, err := createDockerClient()
-	if err != nil {
-		return "", fmt.Errorf("failed to create Docker client: %w", err)
+// GetPullProgress reports the progress recorded by the most recent pull of the given image, so
+// long-running pulls can be polled instead of blocking the caller for the entire download.
+// InitializeEnvironment calls that find the image locally or in the coordinator's cache do not
+// pull, and leave the previous record in place.
+func GetPullProgress(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	image, ok := request.Params.Arguments["image"].(string)
+	if !ok || image == "" {
+		return mcp.NewToolResultText("Error: image is required"), nil
 	}
-	defer cli.Close()
 
-	// Check if image exists locally first
-	_, err = cli.ImageInspect(ctx, image)
-	if err != nil {
-		// Image doesn't exist locally, so pull it
-		fmt.Printf("Docker image %s not found locally, pulling from registry...\n", image)
+	progress := pullProgressFor(image)
+	lines, done, errMsg := progress.snapshot()
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if errMsg != "" {
+		fmt.Fprintf(&b, "error: %s\n", errMsg)
+	}
+	fmt.Fprintf(&b, "done: %t\n", done)
 
-		// Create a context with timeout for the pull operation
-		// This prevents hanging when the image doesn't exist in the registry
-		pullCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
-		defer cancel()
+	return mcp.NewToolResultText(b.String()), nil
+}
 
-		pullReader, pullErr := cli.ImagePull(pullCtx, image, dockerImage.PullOptions{})
-		if pullErr != nil {
-			// Check if this is a timeout error
-			if pullCtx.Err() == context.DeadlineExceeded {
-				return "", fmt.Errorf("timeout while trying to pull Docker image %s - this usually means the image doesn't exist in the registry or the registry is unreachable", image)
-			}
-			// Check for common "not found" error patterns
-			errStr := pullErr.Error()
-			if strings.Contains(errStr, "not found") || strings.Contains(errStr, "404") || strings.Contains(errStr, "manifest unknown") {
-				return "", fmt.Errorf("docker image %s not found in registry. Please check that the image name and tag are correct", image)
-			}
-			return "", fmt.Errorf("failed to pull Docker image %s: %w", image, pullErr)
+// isImageNotFoundErr classifies a pull error as "image not found in registry", which the
+// DockerImageCoordinator uses to pick the (shorter) negative-cache TTL.
+func isImageNotFoundErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	errStr := err.Error()
+	return strings.Contains(errStr, "not found") || strings.Contains(errStr, "404") || strings.Contains(errStr, "manifest unknown")
+}
+
+// pullImage pulls image via cli, decoding the stream as newline-delimited
+// jsonmessage.JSONMessage so progress can be recorded and errorDetail (not string scanning)
+// is used to detect failures. It runs on its own timeout so a slow/unreachable registry
+// doesn't hang a coordinated pull forever.
+func pullImage(ctx context.Context, cli *client.Client, image string, registryAuth string) error {
+	pullCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	pullReader, pullErr := cli.ImagePull(pullCtx, image, dockerImage.PullOptions{RegistryAuth: registryAuth})
+	if pullErr != nil {
+		if pullCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timeout while trying to pull Docker image %s - this usually means the image doesn't exist in the registry or the registry is unreachable", image)
+		}
+		if isImageNotFoundErr(pullErr) {
+			return fmt.Errorf("docker image %s not found in registry. Please check that the image name and tag are correct", image)
 		}
-		defer pullReader.Close()
+		return fmt.Errorf("failed to pull Docker image %s: %w", image, pullErr)
+	}
+	defer pullReader.Close()
+
+	progress := pullProgressFor(image)
+	progress.reset()
 
-		// Read the pull response to ensure it completes
-		// This also provides feedback on the pull progress
-		pullOutput, readErr := io.ReadAll(pullReader)
-		if readErr != nil {
-			// Check if this is a timeout error
+	decoder := json.NewDecoder(pullReader)
+	var pullErrMsg string
+	for {
+		var msg jsonmessage.JSONMessage
+		if decodeErr := decoder.Decode(&msg); decodeErr != nil {
+			if decodeErr == io.EOF {
+				break
+			}
 			if pullCtx.Err() == context.DeadlineExceeded {
-				return "", fmt.Errorf("timeout while downloading Docker image %s", image)
+				return fmt.Errorf("timeout while downloading Docker image %s", image)
 			}
-			return "", fmt.Errorf("failed to read pull response for image %s: %w", image, readErr)
+			return fmt.Errorf("failed to read pull response for image %s: %w", image, decodeErr)
+		}
+		progress.appendMessage(msg)
+		if msg.Error != nil {
+			pullErrMsg = msg.Error.Message
+		}
+	}
+	progress.finish(pullErrMsg)
+
+	if pullErrMsg != "" {
+		if isImageNotFoundErr(fmt.Errorf("%s", pullErrMsg)) {
+			return fmt.Errorf("docker image %s not found in registry. Please check that the image name and tag are correct", image)
 		}
+		return fmt.Errorf("failed to pull Docker image %s: %s", image, pullErrMsg)
+	}
+	return nil
+}
+
+// createContainer creates a new Docker container and returns its ID
+func createContainer(ctx context.Context, image string, policy pullPolicy, registryAuth string, sandbox SandboxPolicy) (string, error) {
+	// Try to create Docker client with multiple fallback options
+	cli, err := createDockerClient()
+	if err != nil {
+		return "", fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer cli.Close()
 
-		// Check if pull was successful by looking for error messages in output
-		pullStr := string(pullOutput)
-		if strings.Contains(pullStr, "not found") || strings.Contains(pullStr, "404") || strings.Contains(pullStr, "manifest unknown") {
-			return "", fmt.Errorf("docker image %s not found in registry. Please check that the image name and tag are correct", image)
+	// Check if image exists locally first, unless the caller forced a fresh pull
+	imageExistsLocally := false
+	if policy != pullPolicyAlways {
+		_, inspectErr := cli.ImageInspect(ctx, image)
+		imageExistsLocally = inspectErr == nil
+	}
+
+	switch {
+	case policy == pullPolicyNever && !imageExistsLocally:
+		return "", fmt.Errorf("docker image %s not found locally and pull_policy is \"never\"", image)
+	case imageExistsLocally && policy != pullPolicyAlways:
+		fmt.Printf("Docker image %s found locally\n", image)
+	default:
+		if policy == pullPolicyAlways {
+			fmt.Printf("Pulling Docker image %s from registry (pull_policy is \"always\")...\n", image)
+		} else {
+			fmt.Printf("Docker image %s not found locally, pulling from registry...\n", image)
 		}
-		if strings.Contains(pullStr, "error") || strings.Contains(pullStr, "Error") {
-			return "", fmt.Errorf("failed to pull Docker image %s: %s", image, pullStr)
+
+		forceFresh := policy == pullPolicyAlways
+		pullErr := getImageCoordinator().EnsurePulled(ctx, image, registryAuth, forceFresh, isImageNotFoundErr)
+		if pullErr != nil {
+			return "", pullErr
 		}
 
 		fmt.Printf("Successfully pulled Docker image %s\n", image)
-	} else {
-		fmt.Printf("Docker image %s found locally\n", image)
 	}
 
 	// Create container config with a working directory
@@ -99,10 +242,9 @@ func createContainer(ctx context.Context, image string) (string, error) {
 		StdinOnce:  false,
 	}
 
-	// Create host config
-	hostConfig := &container.HostConfig{
-		// Add any resource constraints here if needed
-	}
+	// Create host config, translating the sandbox policy into resource limits, network
+	// isolation and filesystem posture
+	hostConfig := sandbox.toHostConfig()
 
 	// Create the container
 	resp, err := cli.ContainerCreate(
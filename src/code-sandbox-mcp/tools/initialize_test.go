@@ -0,0 +1,123 @@
+package tools
+
+import "testing"
+
+func TestParsePullPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    pullPolicy
+		wantErr bool
+	}{
+		{name: "default", raw: "", want: pullPolicyMissing},
+		{name: "missing", raw: "missing", want: pullPolicyMissing},
+		{name: "always", raw: "always", want: pullPolicyAlways},
+		{name: "never", raw: "never", want: pullPolicyNever},
+		{name: "invalid", raw: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePullPolicy(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePullPolicy(%q) = %v, nil; want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePullPolicy(%q) unexpected error: %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Fatalf("parsePullPolicy(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryAuthFromArguments(t *testing.T) {
+	t.Run("absent", func(t *testing.T) {
+		got, err := registryAuthFromArguments(map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "" {
+			t.Fatalf("got %q, want empty string", got)
+		}
+	})
+
+	t.Run("pre-encoded token string", func(t *testing.T) {
+		got, err := registryAuthFromArguments(map[string]interface{}{
+			"registry_auth": "already-base64-token",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "already-base64-token" {
+			t.Fatalf("got %q, want %q", got, "already-base64-token")
+		}
+	})
+
+	t.Run("username/password object", func(t *testing.T) {
+		got, err := registryAuthFromArguments(map[string]interface{}{
+			"registry_auth": map[string]interface{}{
+				"username": "alice",
+				"password": "hunter2",
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got == "" {
+			t.Fatalf("got empty string, want an encoded auth token")
+		}
+	})
+
+	t.Run("object missing username", func(t *testing.T) {
+		_, err := registryAuthFromArguments(map[string]interface{}{
+			"registry_auth": map[string]interface{}{
+				"password": "hunter2",
+			},
+		})
+		if err == nil {
+			t.Fatal("expected an error for missing username")
+		}
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		_, err := registryAuthFromArguments(map[string]interface{}{
+			"registry_auth": 42,
+		})
+		if err == nil {
+			t.Fatal("expected an error for an unsupported registry_auth type")
+		}
+	})
+}
+
+func TestIsImageNotFoundErr(t *testing.T) {
+	tests := []struct {
+		errStr string
+		want   bool
+	}{
+		{errStr: "image not found", want: true},
+		{errStr: "pull access denied, 404", want: true},
+		{errStr: "manifest unknown: manifest unknown", want: true},
+		{errStr: "connection reset by peer", want: false},
+	}
+
+	for _, tt := range tests {
+		got := isImageNotFoundErr(errString(tt.errStr))
+		if got != tt.want {
+			t.Errorf("isImageNotFoundErr(%q) = %v, want %v", tt.errStr, got, tt.want)
+		}
+	}
+
+	if isImageNotFoundErr(nil) {
+		t.Error("isImageNotFoundErr(nil) = true, want false")
+	}
+}
+
+// errString is a minimal error implementation so tests can build ad-hoc errors inline.
+type errString string
+
+func (e errString) Error() string { return string(e) }
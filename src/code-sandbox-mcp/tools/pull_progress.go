@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"sync"
+	"time"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// maxTrackedPullImages bounds how many distinct image references' progress logs are kept in
+// memory at once. Without a cap, a long-running server that pulls many distinct images over its
+// lifetime would leak one entry (and its line history) per image forever.
+const maxTrackedPullImages = 200
+
+// maxProgressLines bounds how many status lines a single pullProgressLog retains. Without this,
+// a large multi-layer pull emitting one line per layer per progress update could grow a single
+// image's log into the thousands of entries.
+const maxProgressLines = 500
+
+// pullProgressLog keeps the most recent pull status lines for an image, so clients can
+// poll GetPullProgress while a long InitializeEnvironment call is still pulling. Repeated
+// updates for the same layer (jsonmessage.JSONMessage.ID) collapse into a single line rather
+// than appending one per update, since that's what drives most of a pull's line volume.
+type pullProgressLog struct {
+	mu        sync.Mutex
+	lines     []string
+	lineForID map[string]int // layer ID -> index into lines of its most recent update
+	done      bool
+	errMsg    string
+}
+
+// trackedPullProgress pairs a pullProgressLog with the last time it was touched, so
+// pullProgressFor can evict the least-recently-used entry once maxTrackedPullImages is reached.
+type trackedPullProgress struct {
+	log          *pullProgressLog
+	lastAccessed time.Time
+}
+
+var (
+	pullProgressMu sync.Mutex
+	pullProgress   = map[string]*trackedPullProgress{}
+)
+
+// pullProgressFor returns (creating if necessary) the progress log for an image reference,
+// evicting the least-recently-used tracked image if this would exceed maxTrackedPullImages.
+func pullProgressFor(image string) *pullProgressLog {
+	pullProgressMu.Lock()
+	defer pullProgressMu.Unlock()
+
+	tracked, ok := pullProgress[image]
+	if ok {
+		tracked.lastAccessed = time.Now()
+		return tracked.log
+	}
+
+	if len(pullProgress) >= maxTrackedPullImages {
+		evictLRUPullProgressLocked()
+	}
+
+	tracked = &trackedPullProgress{log: &pullProgressLog{}, lastAccessed: time.Now()}
+	pullProgress[image] = tracked
+	return tracked.log
+}
+
+// evictLRUPullProgressLocked removes the least-recently-used tracked image. Callers must hold
+// pullProgressMu.
+func evictLRUPullProgressLocked() {
+	var oldestImage string
+	var oldestAt time.Time
+	for image, tracked := range pullProgress {
+		if oldestImage == "" || tracked.lastAccessed.Before(oldestAt) {
+			oldestImage = image
+			oldestAt = tracked.lastAccessed
+		}
+	}
+	if oldestImage != "" {
+		delete(pullProgress, oldestImage)
+	}
+}
+
+func (l *pullProgressLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = nil
+	l.lineForID = nil
+	l.done = false
+	l.errMsg = ""
+}
+
+func (l *pullProgressLog) appendMessage(msg jsonmessage.JSONMessage) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if msg.Error != nil {
+		l.errMsg = msg.Error.Message
+		return
+	}
+
+	line := msg.Status
+	if msg.Progress != nil && msg.Progress.String() != "" {
+		line = line + " " + msg.Progress.String()
+	}
+	if msg.ID != "" {
+		line = msg.ID + ": " + line
+		if idx, ok := l.lineForID[msg.ID]; ok {
+			l.lines[idx] = line
+			return
+		}
+	}
+
+	l.lines = append(l.lines, line)
+	if msg.ID != "" {
+		if l.lineForID == nil {
+			l.lineForID = make(map[string]int)
+		}
+		l.lineForID[msg.ID] = len(l.lines) - 1
+	}
+
+	if len(l.lines) > maxProgressLines {
+		l.lines = l.lines[1:]
+		for id, idx := range l.lineForID {
+			if idx == 0 {
+				delete(l.lineForID, id)
+			} else {
+				l.lineForID[id] = idx - 1
+			}
+		}
+	}
+}
+
+func (l *pullProgressLog) finish(errMsg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.done = true
+	if errMsg != "" {
+		l.errMsg = errMsg
+	}
+}
+
+// snapshot returns a copy of the current lines, whether the pull has finished, and the
+// last recorded error message (if any).
+func (l *pullProgressLog) snapshot() (lines []string, done bool, errMsg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.lines...), l.done, l.errMsg
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+func TestPullProgressLogLifecycle(t *testing.T) {
+	log := &pullProgressLog{}
+
+	log.appendMessage(jsonmessage.JSONMessage{ID: "abc123", Status: "Downloading"})
+	log.appendMessage(jsonmessage.JSONMessage{ID: "def456", Status: "Pull complete"})
+
+	lines, done, errMsg := log.snapshot()
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %v", len(lines), lines)
+	}
+	if done {
+		t.Fatal("snapshot reports done before finish() was called")
+	}
+	if errMsg != "" {
+		t.Fatalf("got errMsg %q, want empty", errMsg)
+	}
+
+	log.finish("")
+	_, done, _ = log.snapshot()
+	if !done {
+		t.Fatal("snapshot reports not done after finish(\"\")")
+	}
+
+	log.reset()
+	lines, done, errMsg = log.snapshot()
+	if len(lines) != 0 || done || errMsg != "" {
+		t.Fatalf("reset() left stale state: lines=%v done=%v errMsg=%q", lines, done, errMsg)
+	}
+}
+
+func TestPullProgressLogCollapsesRepeatedLayerUpdates(t *testing.T) {
+	log := &pullProgressLog{}
+
+	for i := 0; i < 50; i++ {
+		log.appendMessage(jsonmessage.JSONMessage{ID: "layer1", Status: "Downloading"})
+	}
+	log.appendMessage(jsonmessage.JSONMessage{ID: "layer2", Status: "Downloading"})
+
+	lines, _, _ := log.snapshot()
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2 (repeated updates to the same layer ID should collapse): %v", len(lines), lines)
+	}
+}
+
+func TestPullProgressLogCapsTotalLines(t *testing.T) {
+	log := &pullProgressLog{}
+
+	for i := 0; i < maxProgressLines+50; i++ {
+		log.appendMessage(jsonmessage.JSONMessage{Status: "some one-off status line"})
+	}
+
+	lines, _, _ := log.snapshot()
+	if len(lines) > maxProgressLines {
+		t.Fatalf("got %d lines, want at most %d", len(lines), maxProgressLines)
+	}
+}
+
+func TestPullProgressLogErrorMessage(t *testing.T) {
+	log := &pullProgressLog{}
+	log.appendMessage(jsonmessage.JSONMessage{Error: &jsonmessage.JSONError{Message: "manifest unknown"}})
+
+	_, _, errMsg := log.snapshot()
+	if errMsg != "manifest unknown" {
+		t.Fatalf("got errMsg %q, want %q", errMsg, "manifest unknown")
+	}
+}
+
+func TestPullProgressForReusesSameLog(t *testing.T) {
+	const image = "test/pull-progress-reuse:latest"
+	t.Cleanup(func() {
+		pullProgressMu.Lock()
+		delete(pullProgress, image)
+		pullProgressMu.Unlock()
+	})
+
+	first := pullProgressFor(image)
+	second := pullProgressFor(image)
+	if first != second {
+		t.Fatal("pullProgressFor returned a different log for the same image reference")
+	}
+}
+
+func TestEvictLRUPullProgressLocked(t *testing.T) {
+	pullProgressMu.Lock()
+	defer pullProgressMu.Unlock()
+
+	oldKey, midKey, newKey := "test/evict-old", "test/evict-mid", "test/evict-new"
+	defer func() {
+		delete(pullProgress, oldKey)
+		delete(pullProgress, midKey)
+		delete(pullProgress, newKey)
+	}()
+
+	now := time.Now()
+	pullProgress[oldKey] = &trackedPullProgress{log: &pullProgressLog{}, lastAccessed: now.Add(-time.Hour)}
+	pullProgress[midKey] = &trackedPullProgress{log: &pullProgressLog{}, lastAccessed: now.Add(-time.Minute)}
+	pullProgress[newKey] = &trackedPullProgress{log: &pullProgressLog{}, lastAccessed: now}
+
+	evictLRUPullProgressLocked()
+
+	if _, ok := pullProgress[oldKey]; ok {
+		t.Error("evictLRUPullProgressLocked did not remove the least-recently-used entry")
+	}
+	if _, ok := pullProgress[midKey]; !ok {
+		t.Error("evictLRUPullProgressLocked removed an entry that wasn't the oldest")
+	}
+	if _, ok := pullProgress[newKey]; !ok {
+		t.Error("evictLRUPullProgressLocked removed an entry that wasn't the oldest")
+	}
+}
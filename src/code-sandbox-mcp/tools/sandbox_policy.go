@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+)
+
+// SandboxPolicy describes the resource limits, isolation and filesystem posture applied to a
+// container's HostConfig. The zero value is intentionally unsafe (no limits, host networking
+// semantics deferred to Docker's default) - callers should start from a preset via
+// sandboxPresets and layer overrides on top, rather than building one from scratch.
+type SandboxPolicy struct {
+	// CPUQuota and CPUPeriod bound CPU time the same way `docker run --cpu-quota/--cpu-period`
+	// does; NanoCPUs is the simpler `--cpus` equivalent. The two are mutually exclusive as far
+	// as the Docker daemon is concerned. When an override sets only one of them on top of a
+	// preset, parseSandboxPolicy clears the other; an override that sets both keeps both.
+	CPUQuota  int64
+	CPUPeriod int64
+	NanoCPUs  int64
+
+	// Memory and MemorySwap are bytes, matching `--memory`/`--memory-swap`.
+	Memory     int64
+	MemorySwap int64
+
+	// PidsLimit caps the number of processes/threads the container can create.
+	PidsLimit int64
+
+	// ReadonlyRootfs mounts the container's root filesystem read-only.
+	ReadonlyRootfs bool
+
+	// TmpfsSizeBytes, if non-zero and ReadonlyRootfs is set, mounts a size-bounded tmpfs at /tmp
+	// so a read-only rootfs can still scratch-write. It is ignored for a writable rootfs.
+	TmpfsSizeBytes int64
+
+	// NetworkMode matches `--network` ("none", "bridge", "host", ...).
+	NetworkMode string
+
+	// CapDrop and CapAdd match `--cap-drop`/`--cap-add`.
+	CapDrop []string
+	CapAdd  []string
+
+	// SecurityOpt matches `--security-opt` (seccomp/apparmor profiles, no-new-privileges, ...).
+	SecurityOpt []string
+
+	// Ulimits matches `--ulimit`.
+	Ulimits []*units.Ulimit
+}
+
+// sandboxPresets are named starting points for SandboxPolicy, so MCP clients don't have to set
+// every field to get a reasonable posture.
+var sandboxPresets = map[string]SandboxPolicy{
+	// untrusted is the default: no network, dropped capabilities, read-only rootfs with a small
+	// writable /tmp, and modest CPU/memory/pids limits. Appropriate for running code from a
+	// source you don't control.
+	"untrusted": {
+		Memory:         512 * 1024 * 1024,
+		MemorySwap:     512 * 1024 * 1024,
+		NanoCPUs:       1_000_000_000, // 1 CPU
+		PidsLimit:      256,
+		ReadonlyRootfs: true,
+		TmpfsSizeBytes: 64 * 1024 * 1024,
+		NetworkMode:    "none",
+		CapDrop:        []string{"ALL"},
+		SecurityOpt:    []string{"no-new-privileges"},
+	},
+	// network keeps the "untrusted" posture (read-only rootfs, dropped capabilities,
+	// no-new-privileges) but enables bridge networking and doubles the memory, CPU, pids and /tmp
+	// limits, for workloads that need outbound access (package installs, API calls).
+	"network": {
+		Memory:         1024 * 1024 * 1024,
+		MemorySwap:     1024 * 1024 * 1024,
+		NanoCPUs:       2_000_000_000, // 2 CPUs
+		PidsLimit:      512,
+		ReadonlyRootfs: true,
+		TmpfsSizeBytes: 128 * 1024 * 1024,
+		NetworkMode:    "bridge",
+		CapDrop:        []string{"ALL"},
+		SecurityOpt:    []string{"no-new-privileges"},
+	},
+	// privileged sets no sandbox limits, for trusted workloads: writable rootfs, bridge
+	// networking, and Docker's default capabilities and security options. It does not run the
+	// container in Docker's --privileged mode.
+	"privileged": {
+		NetworkMode: "bridge",
+	},
+}
+
+// parseSandboxPolicy builds a SandboxPolicy from the "sandbox_preset" and "sandbox" request
+// arguments. sandbox_preset selects the starting preset (default "untrusted"); sandbox, if
+// present, is an object of field overrides applied on top of it.
+func parseSandboxPolicy(args map[string]interface{}) (SandboxPolicy, error) {
+	presetName, _ := args["sandbox_preset"].(string)
+	if presetName == "" {
+		presetName = "untrusted"
+	}
+	policy, ok := sandboxPresets[presetName]
+	if !ok {
+		return SandboxPolicy{}, fmt.Errorf("invalid sandbox_preset %q: must be one of \"untrusted\", \"network\", \"privileged\"", presetName)
+	}
+
+	overrides, ok := args["sandbox"].(map[string]interface{})
+	if !ok {
+		return policy, nil
+	}
+
+	// CPUQuota/CPUPeriod and NanoCPUs are mutually exclusive as far as the Docker daemon is
+	// concerned ("Conflicting options: Nano CPUs and CPU Period/CPU Quota cannot both be set").
+	// Whichever one the caller explicitly overrides wins over whatever the preset set for the
+	// other.
+	_, quotaOverridden := overrides["cpu_quota"]
+	_, periodOverridden := overrides["cpu_period"]
+	_, nanoCPUsOverridden := overrides["nano_cpus"]
+
+	if v, ok := overrides["cpu_quota"].(float64); ok {
+		policy.CPUQuota = int64(v)
+	}
+	if v, ok := overrides["cpu_period"].(float64); ok {
+		policy.CPUPeriod = int64(v)
+	}
+	if v, ok := overrides["nano_cpus"].(float64); ok {
+		policy.NanoCPUs = int64(v)
+	}
+	if (quotaOverridden || periodOverridden) && !nanoCPUsOverridden {
+		policy.NanoCPUs = 0
+	} else if nanoCPUsOverridden && !quotaOverridden && !periodOverridden {
+		policy.CPUQuota = 0
+		policy.CPUPeriod = 0
+	}
+	if v, ok := overrides["memory"].(float64); ok {
+		policy.Memory = int64(v)
+	}
+	if v, ok := overrides["memory_swap"].(float64); ok {
+		policy.MemorySwap = int64(v)
+	}
+	if v, ok := overrides["pids_limit"].(float64); ok {
+		policy.PidsLimit = int64(v)
+	}
+	if v, ok := overrides["readonly_rootfs"].(bool); ok {
+		policy.ReadonlyRootfs = v
+	}
+	if v, ok := overrides["tmpfs_size_bytes"].(float64); ok {
+		policy.TmpfsSizeBytes = int64(v)
+	}
+	if v, ok := overrides["network_mode"].(string); ok {
+		policy.NetworkMode = v
+	}
+	if v, ok := overrides["cap_add"].([]interface{}); ok {
+		policy.CapAdd = toStringSlice(v)
+	}
+	if v, ok := overrides["cap_drop"].([]interface{}); ok {
+		policy.CapDrop = toStringSlice(v)
+	}
+	if v, ok := overrides["security_opt"].([]interface{}); ok {
+		policy.SecurityOpt = toStringSlice(v)
+	}
+	if v, ok := overrides["ulimits"].([]interface{}); ok {
+		ulimits, err := toUlimits(v)
+		if err != nil {
+			return SandboxPolicy{}, err
+		}
+		policy.Ulimits = ulimits
+	}
+
+	return policy, nil
+}
+
+// toUlimits converts a decoded JSON array of {"name", "soft", "hard"} objects (matching
+// `docker run --ulimit name=soft:hard`) into *units.Ulimit values.
+func toUlimits(raw []interface{}) ([]*units.Ulimit, error) {
+	out := make([]*units.Ulimit, 0, len(raw))
+	for _, v := range raw {
+		entry, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("ulimits entries must be objects with name/soft/hard")
+		}
+		name, _ := entry["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("ulimits entry missing required \"name\"")
+		}
+		soft, _ := entry["soft"].(float64)
+		hard, _ := entry["hard"].(float64)
+		out = append(out, &units.Ulimit{
+			Name: name,
+			Soft: int64(soft),
+			Hard: int64(hard),
+		})
+	}
+	return out, nil
+}
+
+// toStringSlice converts a decoded JSON array to a []string, skipping any non-string elements.
+func toStringSlice(raw []interface{}) []string {
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// toHostConfig translates a SandboxPolicy into the container.HostConfig used by ContainerCreate.
+func (p SandboxPolicy) toHostConfig() *container.HostConfig {
+	hostConfig := &container.HostConfig{
+		Resources: container.Resources{
+			CPUQuota:  p.CPUQuota,
+			CPUPeriod: p.CPUPeriod,
+			NanoCPUs:  p.NanoCPUs,
+			Memory:    p.Memory,
+			MemorySwap: func() int64 {
+				if p.MemorySwap != 0 {
+					return p.MemorySwap
+				}
+				return p.Memory
+			}(),
+			Ulimits: p.Ulimits,
+		},
+		ReadonlyRootfs: p.ReadonlyRootfs,
+		NetworkMode:    container.NetworkMode(p.NetworkMode),
+		CapDrop:        p.CapDrop,
+		CapAdd:         p.CapAdd,
+		SecurityOpt:    p.SecurityOpt,
+	}
+
+	// PidsLimit is a *int64 specifically so nil means "inherit/no limit" - only populate it when
+	// the policy actually sets one, so e.g. the "privileged" preset (which never sets PidsLimit)
+	// doesn't end up sending an explicit 0-process limit to the daemon.
+	if p.PidsLimit > 0 {
+		hostConfig.Resources.PidsLimit = &p.PidsLimit
+	}
+
+	if p.ReadonlyRootfs && p.TmpfsSizeBytes > 0 {
+		hostConfig.Tmpfs = map[string]string{
+			"/tmp": fmt.Sprintf("size=%d", p.TmpfsSizeBytes),
+		}
+	}
+
+	return hostConfig
+}
@@ -0,0 +1,120 @@
+package tools
+
+import "testing"
+
+func TestParseSandboxPolicyDefaultsToUntrusted(t *testing.T) {
+	policy, err := parseSandboxPolicy(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.NetworkMode != "none" {
+		t.Fatalf("default preset NetworkMode = %q, want %q", policy.NetworkMode, "none")
+	}
+	if !policy.ReadonlyRootfs {
+		t.Fatal("default preset should be read-only")
+	}
+}
+
+func TestParseSandboxPolicyInvalidPreset(t *testing.T) {
+	_, err := parseSandboxPolicy(map[string]interface{}{"sandbox_preset": "yolo"})
+	if err == nil {
+		t.Fatal("expected an error for an unknown sandbox_preset")
+	}
+}
+
+func TestParseSandboxPolicyUlimitsOverride(t *testing.T) {
+	policy, err := parseSandboxPolicy(map[string]interface{}{
+		"sandbox_preset": "untrusted",
+		"sandbox": map[string]interface{}{
+			"ulimits": []interface{}{
+				map[string]interface{}{"name": "nofile", "soft": float64(1024), "hard": float64(2048)},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(policy.Ulimits) != 1 {
+		t.Fatalf("got %d ulimits, want 1", len(policy.Ulimits))
+	}
+	got := policy.Ulimits[0]
+	if got.Name != "nofile" || got.Soft != 1024 || got.Hard != 2048 {
+		t.Fatalf("got ulimit %+v, want {nofile 1024 2048}", got)
+	}
+}
+
+func TestParseSandboxPolicyUlimitsOverrideRejectsMissingName(t *testing.T) {
+	_, err := parseSandboxPolicy(map[string]interface{}{
+		"sandbox": map[string]interface{}{
+			"ulimits": []interface{}{
+				map[string]interface{}{"soft": float64(1), "hard": float64(2)},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a ulimit entry missing \"name\"")
+	}
+}
+
+func TestParseSandboxPolicyCPUQuotaOverrideClearsNanoCPUs(t *testing.T) {
+	// The "untrusted" preset sets NanoCPUs; overriding cpu_quota/cpu_period must not leave
+	// NanoCPUs non-zero too, or the Docker daemon rejects the container create.
+	policy, err := parseSandboxPolicy(map[string]interface{}{
+		"sandbox_preset": "untrusted",
+		"sandbox": map[string]interface{}{
+			"cpu_quota":  float64(50000),
+			"cpu_period": float64(100000),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.NanoCPUs != 0 {
+		t.Fatalf("NanoCPUs = %d, want 0 when cpu_quota/cpu_period are overridden", policy.NanoCPUs)
+	}
+	if policy.CPUQuota != 50000 || policy.CPUPeriod != 100000 {
+		t.Fatalf("got CPUQuota=%d CPUPeriod=%d, want 50000/100000", policy.CPUQuota, policy.CPUPeriod)
+	}
+}
+
+func TestParseSandboxPolicyNanoCPUsOverrideClearsCPUQuota(t *testing.T) {
+	policy, err := parseSandboxPolicy(map[string]interface{}{
+		"sandbox_preset": "untrusted",
+		"sandbox": map[string]interface{}{
+			"nano_cpus": float64(500_000_000),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.CPUQuota != 0 || policy.CPUPeriod != 0 {
+		t.Fatalf("got CPUQuota=%d CPUPeriod=%d, want both 0 when nano_cpus is overridden", policy.CPUQuota, policy.CPUPeriod)
+	}
+	if policy.NanoCPUs != 500_000_000 {
+		t.Fatalf("NanoCPUs = %d, want 500000000", policy.NanoCPUs)
+	}
+}
+
+func TestUntrustedPresetHostConfigDoesNotSetBothCPUFields(t *testing.T) {
+	policy := sandboxPresets["untrusted"]
+	hostConfig := policy.toHostConfig()
+
+	if hostConfig.Resources.NanoCPUs != 0 && (hostConfig.Resources.CPUQuota != 0 || hostConfig.Resources.CPUPeriod != 0) {
+		t.Fatalf("toHostConfig set both NanoCPUs (%d) and CPUQuota/CPUPeriod (%d/%d)",
+			hostConfig.Resources.NanoCPUs, hostConfig.Resources.CPUQuota, hostConfig.Resources.CPUPeriod)
+	}
+}
+
+func TestSandboxPolicyToHostConfigTmpfsOnlyWhenReadonly(t *testing.T) {
+	policy := SandboxPolicy{ReadonlyRootfs: false, TmpfsSizeBytes: 1024}
+	hostConfig := policy.toHostConfig()
+	if len(hostConfig.Tmpfs) != 0 {
+		t.Fatalf("expected no tmpfs mounts when ReadonlyRootfs is false, got %v", hostConfig.Tmpfs)
+	}
+
+	policy.ReadonlyRootfs = true
+	hostConfig = policy.toHostConfig()
+	if hostConfig.Tmpfs["/tmp"] == "" {
+		t.Fatal("expected a /tmp tmpfs mount when ReadonlyRootfs is true and TmpfsSizeBytes > 0")
+	}
+}